@@ -0,0 +1,71 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+)
+
+// Arch describes everything lfi-bind needs to know about a -target: its
+// cross-compiler triple, the musl dynamic linker patchelf should set as the
+// stub's interpreter, the pointer width/byte order its .stack_args encoding
+// was emitted in, and its embedded asm templates.
+type Arch struct {
+	Name        string
+	Triple      string
+	MuslLoader  string
+	PointerSize int
+	ByteOrder   binary.ByteOrder
+
+	CbTrampolines string
+	Stub          string
+	Trampolines   string
+}
+
+//go:embed embed/x86_64-linux/cbtrampolines.s
+var x86_64LinuxCbTrampolines string
+
+//go:embed embed/x86_64-linux/stub.s.in
+var x86_64LinuxStub string
+
+//go:embed embed/x86_64-linux/trampolines.s.in
+var x86_64LinuxTrampolines string
+
+// arches is the registry of supported -target values. Adding a new
+// architecture means adding its embed/<arch>/{cbtrampolines.s,stub.s.in,
+// trampolines.s.in} templates and an entry here.
+var arches = map[string]Arch{
+	"x86_64-linux": {
+		Name:          "x86_64-linux",
+		Triple:        "x86_64-lfi-linux-musl-clang",
+		MuslLoader:    "/lib/ld-musl-x86_64.so.1",
+		PointerSize:   8,
+		ByteOrder:     binary.LittleEndian,
+		CbTrampolines: x86_64LinuxCbTrampolines,
+		Stub:          x86_64LinuxStub,
+		Trampolines:   x86_64LinuxTrampolines,
+	},
+}
+
+// TrampolineName returns the symbol name genTrampolines/genStub generate for
+// sym's trampoline. It is handed to those templates as the "trampoline"
+// template func, so buildManifest can report the exact same name instead of
+// guessing a format that could drift from what the templates actually emit.
+func (a Arch) TrampolineName(sym string) string {
+	return "_lfi_trampoline_" + sym
+}
+
+// LookupArch resolves a -target value to its Arch descriptor. The compiler
+// triple can be overridden per target with the LFICC_<arch> environment
+// variable (e.g. LFICC_x86_64__linux for -target x86_64-linux), falling back
+// to the plain LFICC variable and then to Arch.Triple.
+func LookupArch(target string) (Arch, error) {
+	arch, ok := arches[target]
+	if !ok {
+		return Arch{}, fmt.Errorf("unsupported -target %q (no embed/%s templates)", target, target)
+	}
+
+	arch.Triple = getenv("LFICC_"+ident(target), getenv("LFICC", arch.Triple))
+
+	return arch, nil
+}
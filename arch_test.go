@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestLookupArchUnknownTarget(t *testing.T) {
+	if _, err := LookupArch("made-up-target"); err == nil {
+		t.Error("LookupArch(\"made-up-target\") = nil error, want non-nil")
+	}
+}
+
+func TestLookupArchTripleEnvOverride(t *testing.T) {
+	t.Setenv("LFICC_x86_64__linux", "custom-triple")
+
+	arch, err := LookupArch("x86_64-linux")
+	if err != nil {
+		t.Fatalf("LookupArch: %v", err)
+	}
+	if arch.Triple != "custom-triple" {
+		t.Errorf("Triple = %q, want %q", arch.Triple, "custom-triple")
+	}
+}
+
+func TestLookupArchTriplePlainEnvFallback(t *testing.T) {
+	t.Setenv("LFICC", "fallback-triple")
+
+	arch, err := LookupArch("x86_64-linux")
+	if err != nil {
+		t.Fatalf("LookupArch: %v", err)
+	}
+	if arch.Triple != "fallback-triple" {
+		t.Errorf("Triple = %q, want %q", arch.Triple, "fallback-triple")
+	}
+}
+
+func TestArchTrampolineName(t *testing.T) {
+	arch, err := LookupArch("x86_64-linux")
+	if err != nil {
+		t.Fatalf("LookupArch: %v", err)
+	}
+	if got, want := arch.TrampolineName("foo"), "_lfi_trampoline_foo"; got != want {
+		t.Errorf("TrampolineName(\"foo\") = %q, want %q", got, want)
+	}
+}
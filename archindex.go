@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/erikgeiser/ar"
+)
+
+// archIndexEntry records where one archive member lives in the underlying
+// file, without holding any of its contents in memory.
+type archIndexEntry struct {
+	name   string
+	offset int64
+	size   int64
+}
+
+// mmapReaderAt is a memory-mapped view of a file, read lazily by the OS
+// rather than copied onto the Go heap.
+type mmapReaderAt interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// buildArchIndex makes a single sequential pass over the archive, recording
+// each member's name and byte range. No member's content is read during this
+// pass; symbols are resolved from the index on demand by StaticGetExports.
+func buildArchIndex(staticlib *os.File) ([]archIndexEntry, error) {
+	r, err := ar.NewReader(staticlib)
+	if err != nil {
+		return nil, err
+	}
+
+	var index []archIndexEntry
+	for {
+		hdr, err := r.Next()
+		if err != nil {
+			break
+		}
+
+		offset, err := staticlib.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+
+		index = append(index, archIndexEntry{
+			name:   hdr.Name,
+			offset: offset,
+			size:   hdr.Size,
+		})
+	}
+
+	return index, nil
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// fileReaderAt falls back to plain file reads on platforms without a mmap
+// syscall wired up (e.g. Windows); StaticGetExports still only ever reads
+// the bytes a reachable member actually needs.
+type fileReaderAt struct {
+	f *os.File
+}
+
+func (r *fileReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return r.f.ReadAt(p, off)
+}
+
+func (r *fileReaderAt) Close() error {
+	return nil
+}
+
+func openMmap(f *os.File) (mmapReaderAt, error) {
+	return &fileReaderAt{f: f}, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/erikgeiser/ar"
+)
+
+func writeTestArchive(t *testing.T, members map[string][]byte, order []string) *os.File {
+	t.Helper()
+
+	f, err := os.CreateTemp(t.TempDir(), "archindex_test")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	w := ar.NewWriter(f)
+	for _, name := range order {
+		data := members[name]
+		if err := w.WriteHeader(&ar.Header{Name: name, Size: int64(len(data))}); err != nil {
+			t.Fatalf("write header for %s: %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("write data for %s: %v", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("seek to start: %v", err)
+	}
+	return f
+}
+
+func TestBuildArchIndex(t *testing.T) {
+	members := map[string][]byte{
+		"a.o": []byte("first member contents"),
+		"b.o": []byte("second member, a bit longer than the first"),
+	}
+	order := []string{"a.o", "b.o"}
+
+	f := writeTestArchive(t, members, order)
+
+	index, err := buildArchIndex(f)
+	if err != nil {
+		t.Fatalf("buildArchIndex: %v", err)
+	}
+	if len(index) != len(order) {
+		t.Fatalf("got %d entries, want %d", len(index), len(order))
+	}
+
+	for i, name := range order {
+		entry := index[i]
+		if entry.name != name {
+			t.Errorf("entry %d: name = %q, want %q", i, entry.name, name)
+		}
+		want := members[name]
+		if entry.size != int64(len(want)) {
+			t.Errorf("entry %d (%s): size = %d, want %d", i, name, entry.size, len(want))
+		}
+
+		got := make([]byte, entry.size)
+		if _, err := f.ReadAt(got, entry.offset); err != nil {
+			t.Fatalf("ReadAt entry %d (%s): %v", i, name, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("entry %d (%s): content = %q, want %q", i, name, got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+//go:build unix
+
+package main
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+type mmapFile struct {
+	data []byte
+}
+
+func (m *mmapFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off > int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *mmapFile) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := unix.Munmap(m.data)
+	m.data = nil
+	return err
+}
+
+// openMmap maps the full archive into memory so that members can be handed
+// to OpenObjectFile as io.SectionReaders without ever reading an unreachable
+// member's bytes off disk.
+func openMmap(f *os.File) (mmapReaderAt, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mmapFile{data: data}, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	demangle "github.com/ianlancetaylor/demangle"
+)
+
+//go:embed filters/spidermonkey.txt
+var spidermonkeyFilter string
+
+type ruleVerb int
+
+const (
+	verbPrefix ruleVerb = iota
+	verbSuffix
+	verbContains
+	verbName
+	verbGlob
+	verbRegex
+)
+
+var ruleVerbs = map[string]ruleVerb{
+	"prefix":   verbPrefix,
+	"suffix":   verbSuffix,
+	"contains": verbContains,
+	"name":     verbName,
+	"glob":     verbGlob,
+	"regex":    verbRegex,
+}
+
+// Rule is one allow/deny line from a filter file.
+type Rule struct {
+	allow   bool
+	verb    ruleVerb
+	pattern string
+	mangled bool
+	re      *regexp.Regexp
+}
+
+func (r *Rule) matches(name string) bool {
+	switch r.verb {
+	case verbPrefix:
+		return strings.HasPrefix(name, r.pattern)
+	case verbSuffix:
+		return strings.HasSuffix(name, r.pattern)
+	case verbContains:
+		return strings.Contains(name, r.pattern)
+	case verbName:
+		return name == r.pattern
+	case verbGlob:
+		ok, _ := path.Match(r.pattern, name)
+		return ok
+	case verbRegex:
+		return r.re.MatchString(name)
+	}
+	return false
+}
+
+// Filter is a compiled, ordered list of export-selection rules.
+type Filter struct {
+	rules []Rule
+}
+
+// ParseFilter compiles a filter file such as filters/spidermonkey.txt. Each
+// non-empty, non-comment line has the form:
+//
+//	<+|-><verb> <pattern> [mangled]
+//
+// verb is one of prefix, suffix, contains, name, glob, or regex. Matching is
+// against the demangled symbol name unless "mangled" is given.
+func ParseFilter(r io.Reader) (*Filter, error) {
+	scanner := bufio.NewScanner(r)
+
+	var rules []Rule
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid filter rule: %q", line)
+		}
+
+		verbField := fields[0]
+		if len(verbField) < 2 || (verbField[0] != '+' && verbField[0] != '-') {
+			return nil, fmt.Errorf("invalid filter rule: %q", line)
+		}
+
+		verb, ok := ruleVerbs[verbField[1:]]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter verb: %q", verbField[1:])
+		}
+
+		rule := Rule{
+			allow:   verbField[0] == '+',
+			verb:    verb,
+			pattern: fields[1],
+			mangled: len(fields) > 2 && fields[2] == "mangled",
+		}
+
+		if verb == verbRegex {
+			re, err := regexp.Compile(rule.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %w", rule.pattern, err)
+			}
+			rule.re = re
+		}
+
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Filter{rules: rules}, nil
+}
+
+// ParseFilterFile loads a Filter from path, as given to the -filter flag.
+func ParseFilterFile(path string) (*Filter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseFilter(f)
+}
+
+// DefaultFilter is the SpiderMonkey export policy IsExport applied
+// unconditionally before filters became configurable.
+func DefaultFilter() *Filter {
+	filter, err := ParseFilter(strings.NewReader(spidermonkeyFilter))
+	if err != nil {
+		// filters/spidermonkey.txt ships with the binary; a parse error here
+		// means it was edited into an invalid state.
+		panic(err)
+	}
+	return filter
+}
+
+// Match reports whether mangled should be exported under this filter. Rules
+// are evaluated in file order; the first one whose pattern matches decides
+// the outcome. If no rule matches, the symbol is not exported. The
+// demangled form is only computed the first time a non-"mangled" rule is
+// reached, so a filter of all-mangled rules (or a mangled rule early enough
+// to decide the match) never pays for a demangle it doesn't need.
+func (f *Filter) Match(mangled string) bool {
+	var dsym, after string
+	var found, demangled bool
+
+	for _, rule := range f.rules {
+		name := mangled
+		if !rule.mangled {
+			if !demangled {
+				dsym = demangle.Filter(mangled)
+				_, after, found = strings.Cut(dsym, " ")
+				demangled = true
+			}
+			name = dsym
+		}
+
+		if rule.matches(name) {
+			return rule.allow
+		}
+
+		// A return type before the first space can hide a demangled
+		// prefix match, e.g. "void js::Foo()"; also try the part after it.
+		if !rule.mangled && rule.verb == verbPrefix && found && strings.HasPrefix(after, rule.pattern) {
+			return rule.allow
+		}
+	}
+
+	return false
+}
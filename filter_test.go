@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFilterRejectsInvalidRules(t *testing.T) {
+	cases := []string{
+		"+prefix",
+		"prefix foo",
+		"+unknownverb foo",
+		"+regex (",
+	}
+	for _, c := range cases {
+		if _, err := ParseFilter(strings.NewReader(c)); err == nil {
+			t.Errorf("ParseFilter(%q): expected error, got nil", c)
+		}
+	}
+}
+
+func TestFilterMatchVerbs(t *testing.T) {
+	filter, err := ParseFilter(strings.NewReader(`
++prefix js::
++suffix _internal
++contains Profiling
++name exact_match
++glob sandbox_*
++regex ^JS_[A-Z]
+`))
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+
+	tests := []struct {
+		sym  string
+		want bool
+	}{
+		{"js::Foo", true},
+		{"do_internal", true},
+		{"ProfilingStack", true},
+		{"exact_match", true},
+		{"sandbox_create", true},
+		{"JS_NewContext", true},
+		{"unrelated_symbol", false},
+	}
+	for _, tc := range tests {
+		if got := filter.Match(tc.sym); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.sym, got, tc.want)
+		}
+	}
+}
+
+func TestFilterFirstRuleWins(t *testing.T) {
+	filter, err := ParseFilter(strings.NewReader(`
++prefix js_
+-contains js_
+`))
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+
+	if !filter.Match("js_helper") {
+		t.Error("Match(js_helper) = false, want true (first matching rule allows it)")
+	}
+}
+
+func TestFilterMangledVsDemangled(t *testing.T) {
+	filter, err := ParseFilter(strings.NewReader(`
++name _ZN2js3FooEv mangled
+`))
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+
+	if !filter.Match("_ZN2js3FooEv") {
+		t.Error("Match on mangled name should succeed with the mangled rule")
+	}
+
+	demangledFilter, err := ParseFilter(strings.NewReader(`
++name _ZN2js3FooEv
+`))
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+
+	// Without "mangled" the rule sees "js::Foo()", so the mangled pattern
+	// no longer matches.
+	if demangledFilter.Match("_ZN2js3FooEv") {
+		t.Error("Match on mangled name should fail with a demangled rule")
+	}
+}
+
+func TestFilterNoRuleMatchesDenies(t *testing.T) {
+	filter, err := ParseFilter(strings.NewReader(`+prefix js::`))
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	if filter.Match("some_other_symbol") {
+		t.Error("Match should deny a symbol with no matching rule")
+	}
+}
+
+func TestDefaultFilterMatchesSpiderMonkeyRules(t *testing.T) {
+	filter := DefaultFilter()
+
+	allowed := []string{
+		"JS_NewContext",
+		"_ZN2js8SomeFuncEv", // demangles to a js:: prefixed name
+	}
+	for _, sym := range allowed {
+		if !filter.Match(sym) {
+			t.Errorf("DefaultFilter().Match(%q) = false, want true", sym)
+		}
+	}
+
+	if filter.Match("malloc") {
+		t.Error("DefaultFilter().Match(\"malloc\") = true, want false")
+	}
+}
@@ -1,16 +1,9 @@
 package main
 
 import (
-	"bytes"
-	"debug/elf"
 	"encoding/binary"
 	"io"
-	"log"
 	"os"
-	"strings"
-
-	demangle "github.com/ianlancetaylor/demangle"
-	"github.com/erikgeiser/ar"
 )
 
 var exposed = []string{
@@ -31,152 +24,197 @@ type ExportInfo struct {
 	IsGlobal bool
 }
 
-func IsExport(sym string, exports map[string]bool) bool {
+// IsExport reports whether sym should be exported, either because it is
+// named explicitly in exports or because it is allowed by filter. If filter
+// is nil, DefaultFilter (the SpiderMonkey rules) is used.
+func IsExport(sym string, exports map[string]bool, filter *Filter) bool {
 	if len(exports) > 0 && exports[sym] {
 		return true
 	}
 
-	dsym := demangle.Filter(sym)
-	_, after, found := strings.Cut(dsym, " ")
-
-	if strings.HasPrefix(dsym, "js::") || strings.HasPrefix(dsym, "JS::") || strings.HasPrefix(dsym, "sandbox::") || strings.HasPrefix(dsym, "JS_") || strings.Contains(dsym, "ProfilingStack") || strings.Contains(dsym, "JSStructuredCloneData") || strings.Contains(dsym, "JSAutoRealm") || strings.Contains(dsym, "JSAutoStructuredCloneBuffer") || strings.Contains(dsym, "JSErrorReport") || strings.Contains(dsym, "JSErrorNotes") || strings.Contains(dsym, "JSAutoNullableRealm") || strings.Contains(dsym, "JSPrincipalsWithOps") {
-		return true
-	} else if found && (strings.HasPrefix(after, "js::") || strings.HasPrefix(after, "JS::") || strings.HasPrefix(after, "sandbox::") || strings.HasPrefix(after, "JS_")) {
-		return true
+	if filter == nil {
+		filter = DefaultFilter()
 	}
 
-	return false
+	return filter.Match(sym)
 }
 
-func ObjGetExports(file *elf.File, es map[string]bool) []ExportInfo {
-	syms, err := file.Symbols()
-	if err != nil {
-		fatal(err)
-	}
+func ObjGetExports(file ObjectFile, es map[string]bool, filter *Filter, arch Arch) []ExportInfo {
 	var exports []ExportInfo
-	for _, sym := range syms {
-		if IsExport(sym.Name, es) && (elf.ST_BIND(sym.Info) == elf.STB_GLOBAL && elf.ST_TYPE(sym.Info) == elf.STT_FUNC && sym.Section != elf.SHN_UNDEF) {
-			if sym.Name == "_init" || sym.Name == "_fini" {
-				// Musl inserts these symbols on shared libraries, but after we
-				// compile the stub they will be linked internally, and should
-				// not be exported.
-				continue
-			}
-			exports = append(exports, ExportInfo{ Name: sym.Name, IsGlobal: true })
+	for _, sym := range file.Symbols() {
+		if !sym.IsFunc || !sym.Defined || !(sym.IsGlobal || sym.IsWeak) {
+			continue
 		}
-		if IsExport(sym.Name, es) && (elf.ST_BIND(sym.Info) == elf.STB_WEAK && elf.ST_TYPE(sym.Info) == elf.STT_FUNC && sym.Section != elf.SHN_UNDEF) {
-			if sym.Name == "_init" || sym.Name == "_fini" {
-				// Musl inserts these symbols on shared libraries, but after we
-				// compile the stub they will be linked internally, and should
-				// not be exported.
-				continue
-			}
-			exports = append(exports, ExportInfo{ Name: sym.Name, IsGlobal: false })
+		if !IsExport(sym.Name, es, filter) {
+			continue
 		}
+		if sym.Name == "_init" || sym.Name == "_fini" {
+			// Musl inserts these symbols on shared libraries, but after we
+			// compile the stub they will be linked internally, and should
+			// not be exported.
+			continue
+		}
+		exports = append(exports, ExportInfo{Name: sym.Name, IsGlobal: sym.IsGlobal})
 	}
-	ObjGetStackArgs(file, es)
+	ObjGetStackArgs(file, es, arch)
 	return exports
 }
 
-func DynamicGetExports(dynlib *os.File, es map[string]bool) ([]ExportInfo, StackArgInfo) {
-	f, err := elf.NewFile(dynlib)
+func DynamicGetExports(dynlib *os.File, es map[string]bool, filter *Filter, arch Arch) ([]ExportInfo, StackArgInfo) {
+	f, err := OpenObjectFile(dynlib)
 	if err != nil {
 		fatal(err)
 	}
-	return ObjGetExports(f, es), ObjGetStackArgs(f, es)
+	return ObjGetExports(f, es, filter, arch), ObjGetStackArgs(f, es, arch)
 }
 
-func StaticGetExports(staticlib *os.File, es map[string]bool) ([]ExportInfo, StackArgInfo) {
-	r, err := ar.NewReader(staticlib)
+func StaticGetExports(staticlib *os.File, es map[string]bool, filter *Filter, arch Arch) ([]ExportInfo, StackArgInfo) {
+	index, err := buildArchIndex(staticlib)
+	if err != nil {
+		fatal(err)
+	}
+
+	mapped, err := openMmap(staticlib)
 	if err != nil {
 		fatal(err)
 	}
+	defer mapped.Close()
+
 	var exports []ExportInfo
-	for {
-		_, err := r.Next()
-		if err != nil {
-			break
-		}
-		data, err := io.ReadAll(r)
-		if err != nil {
-			continue
-		}
-		b := bytes.NewReader(data)
-		ef, err := elf.NewFile(b)
+	for _, entry := range index {
+		of, err := OpenObjectFile(io.NewSectionReader(mapped, entry.offset, entry.size))
 		if err != nil {
 			continue
 		}
-		exports = append(exports, ObjGetExports(ef, es)...)
+		exports = append(exports, ObjGetExports(of, es, filter, arch)...)
 	}
 	return exports, StackArgInfo{}
 }
 
 type StackArgInfo struct {
-	Fn   uint64
-	Sret uint32
-	Args map[string][]StackArg
+	Fn   uint64                `json:"fn"`
+	Sret uint32                `json:"sret"`
+	Args map[string][]StackArg `json:"args"`
 }
 
 type StackArg struct {
-	Offset uint32
-	Size   uint32
+	Offset uint32 `json:"offset"`
+	Size   uint32 `json:"size"`
 }
 
-func ObjGetStackArgs(file *elf.File, es map[string]bool) StackArgInfo {
-	sec := file.Section(".stack_args")
-	if sec == nil {
-		return StackArgInfo{}
-	}
+// StackArgEntry is one decoded record from a .stack_args section: a
+// function's address, its sret flag, and the stack offsets/sizes of its
+// out-of-register arguments.
+type StackArgEntry struct {
+	Fn   uint64     `json:"fn"`
+	Sret uint32     `json:"sret"`
+	Args []StackArg `json:"args"`
+}
 
-	syms, err := file.Symbols()
-	if err != nil {
-		log.Fatal(err)
-	}
-	symtab := make(map[uint64]string)
-	for _, sym := range syms {
-		symtab[sym.Value] = sym.Name
+// readUint reads a pointerSize-byte (4 or 8) unsigned integer from the front
+// of b using order.
+func readUint(b []byte, order binary.ByteOrder, pointerSize int) uint64 {
+	if pointerSize == 4 {
+		return uint64(order.Uint32(b))
 	}
+	return order.Uint64(b)
+}
 
-	info := StackArgInfo{
-		Args: make(map[string][]StackArg),
-	}
+// decodeStackArgs is the pure binary decoder for a .stack_args section: it
+// has no knowledge of the enclosing object format or symbol table, so it can
+// be reused anywhere a SectionReader for that section is available. Function
+// addresses are arch.PointerSize bytes wide; every other field is a 4-byte
+// count, offset, or size. All fields use arch.ByteOrder.
+func decodeStackArgs(sec SectionReader, arch Arch) []StackArgEntry {
+	var entries []StackArgEntry
 
-	b := make([]byte, 8)
+	b := make([]byte, arch.PointerSize)
 	idx := uint64(0)
-	for idx < sec.Size {
-		sec.ReadAt(b, int64(idx))
-		idx += 8
-		info.Fn = binary.LittleEndian.Uint64(b)
+	for idx < uint64(sec.Size()) {
+		var entry StackArgEntry
 
 		sec.ReadAt(b, int64(idx))
+		idx += uint64(arch.PointerSize)
+		entry.Fn = readUint(b, arch.ByteOrder, arch.PointerSize)
+
+		sec.ReadAt(b[:4], int64(idx))
 		idx += 4
-		info.Sret = binary.LittleEndian.Uint32(b)
+		entry.Sret = arch.ByteOrder.Uint32(b)
 
-		sec.ReadAt(b, int64(idx))
+		sec.ReadAt(b[:4], int64(idx))
 		idx += 4
-		entries := binary.LittleEndian.Uint32(b)
+		nargs := arch.ByteOrder.Uint32(b)
 
-		var args []StackArg
-		for i := uint32(0); i < entries; i++ {
+		for i := uint32(0); i < nargs; i++ {
 			// stack offset
-			sec.ReadAt(b, int64(idx))
+			sec.ReadAt(b[:4], int64(idx))
 			idx += 4
-			offset := binary.LittleEndian.Uint32(b)
+			offset := arch.ByteOrder.Uint32(b)
 			// size
-			sec.ReadAt(b, int64(idx))
+			sec.ReadAt(b[:4], int64(idx))
 			idx += 4
-			size := binary.LittleEndian.Uint32(b)
+			size := arch.ByteOrder.Uint32(b)
 
-			args = append(args, StackArg{
+			entry.Args = append(entry.Args, StackArg{
 				Offset: offset,
 				Size:   size,
 			})
 		}
 
-		sym := symtab[info.Fn]
-		info.Args[sym] = args
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// ObjGetStackArgEntries decodes file's .stack_args section into one
+// StackArgEntry per exported function, keyed by symbol name, each carrying
+// its own Fn/Sret rather than the single scalar pair ObjGetStackArgs exposes
+// for back-compat.
+func ObjGetStackArgEntries(file ObjectFile, arch Arch) map[string]StackArgEntry {
+	sec := file.Section(".stack_args")
+	if sec == nil {
+		return nil
+	}
+
+	symtab := make(map[uint64]string)
+	for _, sym := range file.Symbols() {
+		symtab[sym.Value] = sym.Name
+	}
+
+	entries := make(map[string]StackArgEntry)
+	for _, entry := range decodeStackArgs(sec, arch) {
+		entries[symtab[entry.Fn]] = entry
+	}
+
+	return entries
+}
+
+// ObjGetStackArgs is the legacy StackArgInfo view of the same decoded
+// .stack_args records: Args is keyed per symbol correctly, but Fn/Sret are
+// whichever entry was decoded last, since StackArgInfo has no room for a
+// per-symbol Fn/Sret. Callers that need the real per-symbol values (e.g. the
+// -manifest output) should use ObjGetStackArgEntries directly.
+func ObjGetStackArgs(file ObjectFile, es map[string]bool, arch Arch) StackArgInfo {
+	sec := file.Section(".stack_args")
+	if sec == nil {
+		return StackArgInfo{}
+	}
+
+	symtab := make(map[uint64]string)
+	for _, sym := range file.Symbols() {
+		symtab[sym.Value] = sym.Name
+	}
+
+	info := StackArgInfo{
+		Args: make(map[string][]StackArg),
+	}
+
+	for _, entry := range decodeStackArgs(sec, arch) {
+		info.Fn = entry.Fn
+		info.Sret = entry.Sret
+		info.Args[symtab[entry.Fn]] = entry.Args
 	}
 
-	//fmt.Println(info)
 	return info
 }
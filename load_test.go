@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// fakeObjectFile is a minimal ObjectFile backed by an in-memory symbol table
+// and a map of named sections, for exercising section/symbol consumers
+// without needing a real ELF/Mach-O/PE-COFF file.
+type fakeObjectFile struct {
+	syms     []Sym
+	sections map[string]SectionReader
+}
+
+func (f *fakeObjectFile) Symbols() []Sym { return f.syms }
+
+func (f *fakeObjectFile) Section(name string) SectionReader {
+	return f.sections[name]
+}
+
+// encodeStackArgs is the inverse of decodeStackArgs, used to build test
+// fixtures for a .stack_args section.
+func encodeStackArgs(entries []StackArgEntry, arch Arch) []byte {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		fn := make([]byte, arch.PointerSize)
+		if arch.PointerSize == 4 {
+			arch.ByteOrder.PutUint32(fn, uint32(entry.Fn))
+		} else {
+			arch.ByteOrder.PutUint64(fn, entry.Fn)
+		}
+		buf.Write(fn)
+
+		var tmp [4]byte
+		arch.ByteOrder.PutUint32(tmp[:], entry.Sret)
+		buf.Write(tmp[:])
+		arch.ByteOrder.PutUint32(tmp[:], uint32(len(entry.Args)))
+		buf.Write(tmp[:])
+		for _, arg := range entry.Args {
+			arch.ByteOrder.PutUint32(tmp[:], arg.Offset)
+			buf.Write(tmp[:])
+			arch.ByteOrder.PutUint32(tmp[:], arg.Size)
+			buf.Write(tmp[:])
+		}
+	}
+	return buf.Bytes()
+}
+
+func testArch() Arch {
+	return Arch{Name: "x86_64-linux", PointerSize: 8, ByteOrder: binary.LittleEndian}
+}
+
+// TestObjGetStackArgEntriesPerSymbol reproduces the scenario from the code
+// review that caught the Fn/Sret bug: two functions with stack args must
+// each keep their own Fn/Sret, not whichever was decoded last.
+func TestObjGetStackArgEntriesPerSymbol(t *testing.T) {
+	arch := testArch()
+
+	fnA := StackArgEntry{Fn: 0x1000, Sret: 0, Args: []StackArg{{Offset: 8, Size: 4}}}
+	fnB := StackArgEntry{Fn: 0x2000, Sret: 1, Args: []StackArg{{Offset: 16, Size: 8}, {Offset: 24, Size: 8}}}
+
+	raw := encodeStackArgs([]StackArgEntry{fnA, fnB}, arch)
+	sec := io.NewSectionReader(bytes.NewReader(raw), 0, int64(len(raw)))
+
+	of := &fakeObjectFile{
+		syms: []Sym{
+			{Name: "fnA", Value: 0x1000, IsFunc: true, IsGlobal: true, Defined: true},
+			{Name: "fnB", Value: 0x2000, IsFunc: true, IsGlobal: true, Defined: true},
+		},
+		sections: map[string]SectionReader{".stack_args": sec},
+	}
+
+	entries := ObjGetStackArgEntries(of, arch)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	got, ok := entries["fnA"]
+	if !ok {
+		t.Fatal("missing entry for fnA")
+	}
+	if got.Fn != fnA.Fn || got.Sret != fnA.Sret {
+		t.Errorf("fnA: Fn/Sret = %#x/%d, want %#x/%d", got.Fn, got.Sret, fnA.Fn, fnA.Sret)
+	}
+
+	got, ok = entries["fnB"]
+	if !ok {
+		t.Fatal("missing entry for fnB")
+	}
+	if got.Fn != fnB.Fn || got.Sret != fnB.Sret {
+		t.Errorf("fnB: Fn/Sret = %#x/%d, want %#x/%d", got.Fn, got.Sret, fnB.Fn, fnB.Sret)
+	}
+}
+
+func TestDecodeStackArgsRoundTrip(t *testing.T) {
+	arch := testArch()
+
+	want := []StackArgEntry{
+		{Fn: 0x1000, Sret: 0, Args: []StackArg{{Offset: 8, Size: 4}}},
+		{Fn: 0x2000, Sret: 1, Args: nil},
+	}
+	raw := encodeStackArgs(want, arch)
+	sec := io.NewSectionReader(bytes.NewReader(raw), 0, int64(len(raw)))
+
+	got := decodeStackArgs(sec, arch)
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Fn != want[i].Fn || got[i].Sret != want[i].Sret {
+			t.Errorf("entry %d: Fn/Sret = %#x/%d, want %#x/%d", i, got[i].Fn, got[i].Sret, want[i].Fn, want[i].Sret)
+		}
+		if len(got[i].Args) != len(want[i].Args) {
+			t.Errorf("entry %d: got %d args, want %d", i, len(got[i].Args), len(want[i].Args))
+		}
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bufio"
-	"debug/elf"
 	_ "embed"
 	"flag"
 	"fmt"
@@ -22,18 +21,9 @@ import (
 //go:embed embed/libinit.c
 var libinit string
 
-//go:embed embed/cbtrampolines.s
-var cbtrampolines string
-
-//go:embed embed/stub.s.in
-var stub string
-
 //go:embed embed/stub_thread.c
 var stub_thread string
 
-//go:embed embed/trampolines.s.in
-var trampolines string
-
 //go:embed embed/includes.c.in
 var includes string
 
@@ -102,46 +92,47 @@ func genIncludes(filemap map[string]string, w io.Writer) {
 	})
 }
 
-func genTrampolines(symnames []string, w io.Writer) {
-	execTemplate(w, "trampolines", trampolines, map[string]any{
+func genTrampolines(symnames []string, arch Arch, w io.Writer) {
+	execTemplate(w, "trampolines", arch.Trampolines, map[string]any{
 		"syms":     symnames,
 		"nsyms":    len(symnames),
 		"sbxsyms":  sbxsyms,
 		"nsbxsyms": len(sbxsyms),
-	}, nil)
+	}, map[string]any{
+		"trampoline": arch.TrampolineName,
+	})
 }
 
-func genStub(symnames []string, w io.Writer) {
-	execTemplate(w, "stub", stub, map[string]any{
+func genStub(symnames []string, arch Arch, w io.Writer) {
+	execTemplate(w, "stub", arch.Stub, map[string]any{
 		"syms":    symnames,
 		"sbxsyms": sbxsyms,
-	}, nil)
+	}, map[string]any{
+		"trampoline": arch.TrampolineName,
+	})
 }
 
-func getSoExports(ef *elf.File) []string {
-	syms, err := ef.Symbols()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var exports []elf.Symbol
-	for _, sym := range syms {
-		if elf.ST_BIND(sym.Info) == elf.STB_GLOBAL && elf.ST_TYPE(sym.Info) == elf.STT_FUNC && sym.Section != elf.SHN_UNDEF {
+// getSoExports returns the names of the global function symbols of of. If
+// filter is non-nil, a symbol must also be allowed by filter to be included;
+// this is how -filter narrows down exports for libraries that shouldn't
+// export everything global, e.g. a C++ library exporting its entire ABI.
+func getSoExports(of ObjectFile, filter *Filter) []string {
+	var exportnames []string
+	for _, sym := range of.Symbols() {
+		if sym.IsGlobal && sym.IsFunc && sym.Defined {
 			if sym.Name == "_init" || sym.Name == "_fini" {
 				// Musl inserts these symbols on shared libraries, but after we
 				// compile the stub they will be linked internally, and should
 				// not be exported.
 				continue
 			}
-			exports = append(exports, sym)
+			if filter != nil && !filter.Match(sym.Name) {
+				continue
+			}
+			exportnames = append(exportnames, sym.Name)
 		}
 	}
 
-	var exportnames []string
-	for _, sym := range exports {
-		exportnames = append(exportnames, sym.Name)
-	}
-
 	return exportnames
 }
 
@@ -198,6 +189,9 @@ func main() {
 	})
 	
 	symFileFlag := flag.String("s", "", "exported symbols file")
+	filterFlag := flag.String("filter", "", "export filter file (default: export everything global)")
+	manifestFlag := flag.String("manifest", "", "write a JSON ABI manifest describing the generated bindings to path")
+	targetFlag := flag.String("target", "x86_64-linux", "target architecture (only x86_64-linux is supported)")
 
 	flag.Parse()
 	args := flag.Args()
@@ -209,20 +203,48 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	ef, err := elf.NewFile(f)
+	of, err := OpenObjectFile(f)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	arch, err := LookupArch(*targetFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var filter *Filter
+	if *filterFlag != "" {
+		filter, err = ParseFilterFile(*filterFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	symFile := *symFileFlag
 
 	var exportnames []string
 	if symFile == "" {
-		exportnames = getSoExports(ef)
+		exportnames = getSoExports(of, filter)
 	} else {
 		exportnames = getFileExports(symFile)
 	}
-	
+
+	if *manifestFlag != "" {
+		exports := make([]ExportInfo, len(exportnames))
+		for i, name := range exportnames {
+			exports[i] = ExportInfo{Name: name, IsGlobal: true}
+		}
+
+		manifest, err := buildManifest(f, exports, ObjGetStackArgEntries(of, arch), arch)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeManifest(*manifestFlag, manifest); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	gen := "gen"
 	os.MkdirAll(gen, os.ModePerm)
 
@@ -254,7 +276,7 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	_, err = fcbtramp.WriteString(cbtrampolines)
+	_, err = fcbtramp.WriteString(arch.CbTrampolines)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -265,17 +287,15 @@ func main() {
 
 	stubgen := filepath.Join(gen, "stub.elf")
 
-	lficc := getenv("LFICC", "x86_64-lfi-linux-musl-clang")
-
-	genStub(exportnames, fstub)
+	genStub(exportnames, arch, fstub)
 
-	genTrampolines(exportnames, ftrampolines)
+	genTrampolines(exportnames, arch, ftrampolines)
 
 	fstub.Close()
 	ftrampolines.Close()
 
-	run(lficc, fstub.Name(), fstub_thread.Name(), "-o", stubgen, "-L"+filepath.Dir(solib), "-l"+libname(solib), "-lstdc++")
-	run("patchelf", "--set-interpreter", "/lib/ld-musl-x86_64.so.1", stubgen)
+	run(arch.Triple, fstub.Name(), fstub_thread.Name(), "-o", stubgen, "-L"+filepath.Dir(solib), "-l"+libname(solib), "-lstdc++")
+	run("patchelf", "--set-interpreter", arch.MuslLoader, stubgen)
 	objmap["stub"] = stubgen
 
 	genIncludes(objmap, fincludes)
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+
+	demangle "github.com/ianlancetaylor/demangle"
+)
+
+// ManifestSymbol describes one exported symbol's generated ABI: its
+// mangled/demangled names, binding, stack argument layout, and the
+// trampoline symbol lfi-bind generated for it.
+type ManifestSymbol struct {
+	Mangled    string         `json:"mangled"`
+	Demangled  string         `json:"demangled"`
+	IsGlobal   bool           `json:"is_global"`
+	Trampoline string         `json:"trampoline"`
+	StackArgs  *StackArgEntry `json:"stack_args,omitempty"`
+}
+
+// Manifest is the -manifest output: a machine-readable description of the
+// generated bindings, so build systems (Bazel, CMake) can cache
+// regenerations instead of grepping the emitted .s/.c files.
+type Manifest struct {
+	InputHash string           `json:"input_hash"`
+	SbxSyms   []string         `json:"sbxsyms"`
+	Exposed   []string         `json:"exposed"`
+	Symbols   []ManifestSymbol `json:"symbols"`
+}
+
+func hashFile(f *os.File) (string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	defer f.Seek(0, io.SeekStart)
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// buildManifest assembles a Manifest for the exports and per-symbol
+// stack-arg layout (as returned by ObjGetStackArgEntries) collected from f.
+// arch.TrampolineName fills in ManifestSymbol.Trampoline, the same naming
+// function genTrampolines/genStub hand to their templates, so the manifest
+// can't drift from what was actually generated.
+func buildManifest(f *os.File, exports []ExportInfo, stackArgs map[string]StackArgEntry, arch Arch) (*Manifest, error) {
+	hash, err := hashFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		InputHash: hash,
+		SbxSyms:   sbxsyms,
+		Exposed:   exposed,
+	}
+
+	for _, exp := range exports {
+		sym := ManifestSymbol{
+			Mangled:    exp.Name,
+			Demangled:  demangle.Filter(exp.Name),
+			IsGlobal:   exp.IsGlobal,
+			Trampoline: arch.TrampolineName(exp.Name),
+		}
+		if entry, ok := stackArgs[exp.Name]; ok {
+			sym.StackArgs = &entry
+		}
+		m.Symbols = append(m.Symbols, sym)
+	}
+
+	return m, nil
+}
+
+func writeManifest(path string, m *Manifest) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
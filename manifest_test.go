@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestBuildManifestPerSymbolStackArgs is the table test the code review
+// asked for: two exported functions with distinct stack-arg layouts must
+// each get their own StackArgs entry in the manifest, not one smeared
+// across both (the bug was buildManifest trusting a single scalar
+// Fn/Sret pair shared by every symbol).
+func TestBuildManifestPerSymbolStackArgs(t *testing.T) {
+	arch := testArch()
+
+	f, err := os.CreateTemp(t.TempDir(), "manifest_test")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	defer f.Close()
+
+	exports := []ExportInfo{
+		{Name: "fnA", IsGlobal: true},
+		{Name: "fnB", IsGlobal: true},
+	}
+	stackArgs := map[string]StackArgEntry{
+		"fnA": {Fn: 0x1000, Sret: 0, Args: []StackArg{{Offset: 8, Size: 4}}},
+		"fnB": {Fn: 0x2000, Sret: 1, Args: []StackArg{{Offset: 16, Size: 8}}},
+	}
+
+	m, err := buildManifest(f, exports, stackArgs, arch)
+	if err != nil {
+		t.Fatalf("buildManifest: %v", err)
+	}
+	if len(m.Symbols) != 2 {
+		t.Fatalf("got %d symbols, want 2", len(m.Symbols))
+	}
+
+	byName := make(map[string]ManifestSymbol)
+	for _, sym := range m.Symbols {
+		byName[sym.Mangled] = sym
+	}
+
+	for name, want := range stackArgs {
+		sym, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing manifest symbol for %s", name)
+		}
+		if sym.StackArgs == nil {
+			t.Fatalf("%s: StackArgs is nil", name)
+		}
+		if sym.StackArgs.Fn != want.Fn || sym.StackArgs.Sret != want.Sret {
+			t.Errorf("%s: StackArgs Fn/Sret = %#x/%d, want %#x/%d", name, sym.StackArgs.Fn, sym.StackArgs.Sret, want.Fn, want.Sret)
+		}
+		if wantTrampoline := arch.TrampolineName(name); sym.Trampoline != wantTrampoline {
+			t.Errorf("%s: Trampoline = %q, want %q", name, sym.Trampoline, wantTrampoline)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"io"
+)
+
+// Sym is a format-agnostic view of a symbol table entry, normalized from
+// whichever concrete object format backs an ObjectFile.
+type Sym struct {
+	Name     string
+	Value    uint64
+	IsFunc   bool
+	IsGlobal bool
+	IsWeak   bool
+	Defined  bool
+}
+
+// SectionReader is a named, randomly-readable section of an object file.
+type SectionReader interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// ObjectFile abstracts the handful of operations lfi-bind needs from a
+// shared library or archive member, so ELF, Mach-O, and PE-COFF inputs can
+// be handled by the same export-collection and stack-args logic.
+type ObjectFile interface {
+	Symbols() []Sym
+	Section(name string) SectionReader
+}
+
+// OpenObjectFile sniffs r and returns the ObjectFile backend matching its
+// file magic: ELF, then Mach-O, then PE-COFF.
+func OpenObjectFile(r io.ReaderAt) (ObjectFile, error) {
+	if ef, err := elf.NewFile(r); err == nil {
+		return &elfObjectFile{ef}, nil
+	}
+	if mf, err := macho.NewFile(r); err == nil {
+		return &machoObjectFile{mf}, nil
+	}
+	if pf, err := pe.NewFile(r); err == nil {
+		return &peObjectFile{pf}, nil
+	}
+	return nil, fmt.Errorf("unrecognized object file format")
+}
+
+type elfObjectFile struct {
+	f *elf.File
+}
+
+type elfSectionReader struct {
+	sec *elf.Section
+}
+
+func (s elfSectionReader) ReadAt(p []byte, off int64) (int, error) {
+	return s.sec.ReadAt(p, off)
+}
+
+func (s elfSectionReader) Size() int64 {
+	return int64(s.sec.Size)
+}
+
+func (o *elfObjectFile) Symbols() []Sym {
+	syms, err := o.f.Symbols()
+	if err != nil {
+		fatal(err)
+	}
+	out := make([]Sym, len(syms))
+	for i, sym := range syms {
+		out[i] = Sym{
+			Name:     sym.Name,
+			Value:    sym.Value,
+			IsFunc:   elf.ST_TYPE(sym.Info) == elf.STT_FUNC,
+			IsGlobal: elf.ST_BIND(sym.Info) == elf.STB_GLOBAL,
+			IsWeak:   elf.ST_BIND(sym.Info) == elf.STB_WEAK,
+			Defined:  sym.Section != elf.SHN_UNDEF,
+		}
+	}
+	return out
+}
+
+func (o *elfObjectFile) Section(name string) SectionReader {
+	sec := o.f.Section(name)
+	if sec == nil {
+		return nil
+	}
+	return elfSectionReader{sec}
+}
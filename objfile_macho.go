@@ -0,0 +1,83 @@
+package main
+
+import (
+	"debug/macho"
+	"strings"
+)
+
+// machoSectionAliases maps the logical section names lfi-bind looks up
+// (shared with the ELF backend) to their Mach-O "segment,section" form.
+var machoSectionAliases = map[string]string{
+	".stack_args": "__DATA,__stack_args",
+}
+
+type machoObjectFile struct {
+	f *macho.File
+}
+
+type machoSectionReader struct {
+	sec *macho.Section
+}
+
+func (s machoSectionReader) ReadAt(p []byte, off int64) (int, error) {
+	return s.sec.ReadAt(p, off)
+}
+
+func (s machoSectionReader) Size() int64 {
+	return int64(s.sec.Size)
+}
+
+const (
+	machoAttrPureInstructions = 0x80000000 // S_ATTR_PURE_INSTRUCTIONS
+)
+
+// isCodeSection reports whether the 1-based section index sect (an nlist
+// Sect field) refers to an executable __TEXT section, as opposed to data
+// (globals, vtables, ...) that merely happens to live in some section.
+func (o *machoObjectFile) isCodeSection(sect uint8) bool {
+	if sect == 0 || int(sect) > len(o.f.Sections) {
+		return false
+	}
+	sec := o.f.Sections[sect-1]
+	return sec.Seg == "__TEXT" && sec.Flags&machoAttrPureInstructions != 0
+}
+
+func (o *machoObjectFile) Symbols() []Sym {
+	if o.f.Symtab == nil {
+		return nil
+	}
+	out := make([]Sym, 0, len(o.f.Symtab.Syms))
+	for _, sym := range o.f.Symtab.Syms {
+		// Mach-O's nlist doesn't split symbols into ELF-style STT_FUNC /
+		// STT_OBJECT types; N_EXT (in Type) marks external linkage and
+		// N_WEAK_DEF (in Desc) marks a weak definition.
+		external := sym.Type&0x01 != 0
+		weak := sym.Desc&0x0080 != 0
+		out = append(out, Sym{
+			Name:     sym.Name,
+			Value:    sym.Value,
+			IsFunc:   o.isCodeSection(sym.Sect),
+			IsGlobal: external && !weak,
+			IsWeak:   external && weak,
+			Defined:  sym.Sect != 0,
+		})
+	}
+	return out
+}
+
+func (o *machoObjectFile) Section(name string) SectionReader {
+	if alias, ok := machoSectionAliases[name]; ok {
+		name = alias
+	}
+
+	_, sec, found := strings.Cut(name, ",")
+	if !found {
+		sec = name
+	}
+
+	s := o.f.Section(sec)
+	if s == nil {
+		return nil
+	}
+	return machoSectionReader{s}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"debug/pe"
+)
+
+type peObjectFile struct {
+	f *pe.File
+}
+
+type peSectionReader struct {
+	sec *pe.Section
+}
+
+func (s peSectionReader) ReadAt(p []byte, off int64) (int, error) {
+	return s.sec.ReadAt(p, off)
+}
+
+func (s peSectionReader) Size() int64 {
+	return int64(s.sec.Size)
+}
+
+func (o *peObjectFile) Symbols() []Sym {
+	// debug/pe only surfaces the COFF symbol table, which mingw-built
+	// DLLs keep unless stripped. Reading the export directory for
+	// stripped DLLs is left as a follow-up.
+	out := make([]Sym, 0, len(o.f.COFFSymbols))
+	for _, sym := range o.f.COFFSymbols {
+		name, _ := sym.FullName(o.f.StringTable)
+		out = append(out, Sym{
+			Name:     name,
+			Value:    uint64(sym.Value),
+			IsFunc:   sym.Type == 0x20,                               // IMAGE_SYM_DTYPE_FUNCTION
+			IsGlobal: sym.StorageClass == 2 && sym.SectionNumber > 0, // IMAGE_SYM_CLASS_EXTERNAL
+			IsWeak:   false,
+			Defined:  sym.SectionNumber > 0,
+		})
+	}
+	return out
+}
+
+func (o *peObjectFile) Section(name string) SectionReader {
+	sec := o.f.Section(name)
+	if sec == nil {
+		return nil
+	}
+	return peSectionReader{sec}
+}
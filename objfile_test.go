@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+)
+
+func nameToArray16(name string) [16]byte {
+	var out [16]byte
+	copy(out[:], name)
+	return out
+}
+
+// buildELFFixture hand-builds a minimal 64-bit little-endian ELF relocatable
+// object with a .text (code) section, a .data section, and a symtab
+// exercising global/local/weak/undefined functions and a global data symbol.
+func buildELFFixture(t *testing.T) []byte {
+	t.Helper()
+
+	const (
+		textOff  = 0x200
+		textSize = 16
+		dataOff  = textOff + textSize
+		dataSize = 8
+	)
+
+	type symSpec struct {
+		name  string
+		info  uint8
+		shndx uint16
+		value uint64
+	}
+	syms := []symSpec{
+		{"", 0, 0, 0}, // mandatory null symbol
+		{"myfunc", elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC), 1, 0},
+		{"myglobal", elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), 2, 0},
+		{"localfunc", elf.ST_INFO(elf.STB_LOCAL, elf.STT_FUNC), 1, 8},
+		{"weakfunc", elf.ST_INFO(elf.STB_WEAK, elf.STT_FUNC), 1, 0},
+		{"undefsym", elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC), 0, 0},
+	}
+
+	var strtab bytes.Buffer
+	strtab.WriteByte(0)
+	nameOff := make([]uint32, len(syms))
+	for i, s := range syms {
+		if s.name == "" {
+			continue
+		}
+		nameOff[i] = uint32(strtab.Len())
+		strtab.WriteString(s.name)
+		strtab.WriteByte(0)
+	}
+
+	var symtab bytes.Buffer
+	for i, s := range syms {
+		binary.Write(&symtab, binary.LittleEndian, elf.Sym64{
+			Name:  nameOff[i],
+			Info:  s.info,
+			Other: 0,
+			Shndx: s.shndx,
+			Value: s.value,
+			Size:  0,
+		})
+	}
+
+	var shstrtab bytes.Buffer
+	shstrtab.WriteByte(0)
+	shName := func(name string) uint32 {
+		off := uint32(shstrtab.Len())
+		shstrtab.WriteString(name)
+		shstrtab.WriteByte(0)
+		return off
+	}
+	nameText := shName(".text")
+	nameData := shName(".data")
+	nameSymtab := shName(".symtab")
+	nameStrtab := shName(".strtab")
+	nameShstrtab := shName(".shstrtab")
+
+	symtabSize, strtabSize, shstrtabSize := symtab.Len(), strtab.Len(), shstrtab.Len()
+
+	symtabOff := dataOff + dataSize
+	strtabOff := symtabOff + symtabSize
+	shstrtabOff := strtabOff + strtabSize
+	shoff := shstrtabOff + shstrtabSize
+
+	const nsections = 6 // null, .text, .data, .symtab, .strtab, .shstrtab
+
+	hdr := elf.Header64{
+		Ident:     [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS64), byte(elf.ELFDATA2LSB), byte(elf.EV_CURRENT), byte(elf.ELFOSABI_NONE)},
+		Type:      uint16(elf.ET_REL),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     uint64(shoff),
+		Ehsize:    64,
+		Shentsize: 64,
+		Shnum:     nsections,
+		Shstrndx:  5,
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, hdr)
+	buf.Write(make([]byte, textOff-buf.Len()))
+	buf.Write(make([]byte, textSize)) // .text contents (unused)
+	buf.Write(make([]byte, dataSize)) // .data contents (unused)
+	symtab.WriteTo(&buf)
+	strtab.WriteTo(&buf)
+	shstrtab.WriteTo(&buf)
+
+	sections := []elf.Section64{
+		{}, // null section
+		{Name: nameText, Type: uint32(elf.SHT_PROGBITS), Flags: uint64(elf.SHF_ALLOC | elf.SHF_EXECINSTR), Off: textOff, Size: textSize},
+		{Name: nameData, Type: uint32(elf.SHT_PROGBITS), Flags: uint64(elf.SHF_ALLOC | elf.SHF_WRITE), Off: dataOff, Size: dataSize},
+		{Name: nameSymtab, Type: uint32(elf.SHT_SYMTAB), Off: uint64(symtabOff), Size: uint64(symtabSize), Link: 4, Info: 1, Entsize: elf.Sym64Size},
+		{Name: nameStrtab, Type: uint32(elf.SHT_STRTAB), Off: uint64(strtabOff), Size: uint64(strtabSize)},
+		{Name: nameShstrtab, Type: uint32(elf.SHT_STRTAB), Off: uint64(shstrtabOff), Size: uint64(shstrtabSize)},
+	}
+	for _, sh := range sections {
+		binary.Write(&buf, binary.LittleEndian, sh)
+	}
+
+	return buf.Bytes()
+}
+
+func TestOpenObjectFileELF(t *testing.T) {
+	data := buildELFFixture(t)
+
+	of, err := OpenObjectFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenObjectFile: %v", err)
+	}
+	if _, ok := of.(*elfObjectFile); !ok {
+		t.Fatalf("OpenObjectFile returned %T, want *elfObjectFile", of)
+	}
+
+	byName := make(map[string]Sym)
+	for _, sym := range of.Symbols() {
+		byName[sym.Name] = sym
+	}
+
+	cases := []struct {
+		name     string
+		isFunc   bool
+		isGlobal bool
+		isWeak   bool
+		defined  bool
+	}{
+		{"myfunc", true, true, false, true},
+		{"myglobal", false, true, false, true},
+		{"localfunc", true, false, false, true},
+		{"weakfunc", true, false, true, true},
+		{"undefsym", true, true, false, false},
+	}
+	for _, tc := range cases {
+		sym, ok := byName[tc.name]
+		if !ok {
+			t.Fatalf("missing symbol %s", tc.name)
+		}
+		if sym.IsFunc != tc.isFunc || sym.IsGlobal != tc.isGlobal || sym.IsWeak != tc.isWeak || sym.Defined != tc.defined {
+			t.Errorf("%s: got {IsFunc:%v IsGlobal:%v IsWeak:%v Defined:%v}, want {%v %v %v %v}",
+				tc.name, sym.IsFunc, sym.IsGlobal, sym.IsWeak, sym.Defined, tc.isFunc, tc.isGlobal, tc.isWeak, tc.defined)
+		}
+	}
+
+	sec := of.Section(".text")
+	if sec == nil {
+		t.Fatal("Section(.text) = nil")
+	}
+	if sec.Size() != textSizeConst {
+		t.Errorf("Section(.text).Size() = %d, want %d", sec.Size(), textSizeConst)
+	}
+	if of.Section(".does-not-exist") != nil {
+		t.Error("Section(.does-not-exist) = non-nil, want nil")
+	}
+}
+
+const textSizeConst = 16
+
+// buildMachOFixture hand-builds a minimal 64-bit little-endian Mach-O object
+// file with a __TEXT,__text section (marked executable via
+// S_ATTR_PURE_INSTRUCTIONS) and a __DATA,__data section, exercising the
+// code-vs-data distinction the manifest-generation path depends on.
+func buildMachOFixture(t *testing.T) []byte {
+	t.Helper()
+
+	// The on-disk 64-bit Mach-O header is 32 bytes (7 uint32 fields plus a
+	// reserved uint32), 4 bytes larger than macho.FileHeader itself, which
+	// has no field for the trailing reserved word.
+	const fileHdrSize = 32
+	segCmdSize := binary.Size(macho.Segment64{})
+	sectSize := binary.Size(macho.Section64{})
+	symtabCmdSize := binary.Size(macho.SymtabCmd{})
+
+	segLen := segCmdSize + sectSize
+	cmdsz := 2*segLen + symtabCmdSize
+	headerAndCmds := fileHdrSize + cmdsz
+
+	const (
+		textSize = 16
+		dataSize = 8
+	)
+	textOff := headerAndCmds
+	dataOff := textOff + textSize
+	symOff := dataOff + dataSize
+
+	type symSpec struct {
+		name  string
+		typ   uint8
+		sect  uint8
+		desc  uint16
+		value uint64
+	}
+	const nExt = 0x01
+	const nWeakDef = 0x0080
+	syms := []symSpec{
+		{"myfunc", nExt, 1, 0, 0},
+		{"myglobal", nExt, 2, 0, 0},
+		{"localfunc", 0, 1, 0, 0},
+		{"weakfunc", nExt, 1, nWeakDef, 0},
+		{"undefsym", nExt, 0, 0, 0},
+	}
+
+	var strtab bytes.Buffer
+	strtab.WriteByte(0)
+	nameOff := make([]uint32, len(syms))
+	for i, s := range syms {
+		nameOff[i] = uint32(strtab.Len())
+		strtab.WriteString(s.name)
+		strtab.WriteByte(0)
+	}
+	strOff := symOff + len(syms)*16
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, macho.FileHeader{
+		Magic:  macho.Magic64,
+		Cpu:    macho.CpuAmd64,
+		SubCpu: 3,
+		Type:   macho.TypeObj,
+		Ncmd:   3,
+		Cmdsz:  uint32(cmdsz),
+		Flags:  0,
+	})
+	buf.Write(make([]byte, fileHdrSize-buf.Len())) // trailing reserved word
+
+	binary.Write(&buf, binary.LittleEndian, macho.Segment64{
+		Cmd: macho.LoadCmdSegment64, Len: uint32(segLen),
+		Name: nameToArray16("__TEXT"), Offset: uint64(textOff), Filesz: textSize,
+		Maxprot: 7, Prot: 7, Nsect: 1,
+	})
+	binary.Write(&buf, binary.LittleEndian, macho.Section64{
+		Name: nameToArray16("__text"), Seg: nameToArray16("__TEXT"),
+		Size: textSize, Offset: uint32(textOff), Flags: machoAttrPureInstructions,
+	})
+
+	binary.Write(&buf, binary.LittleEndian, macho.Segment64{
+		Cmd: macho.LoadCmdSegment64, Len: uint32(segLen),
+		Name: nameToArray16("__DATA"), Offset: uint64(dataOff), Filesz: dataSize,
+		Maxprot: 7, Prot: 7, Nsect: 1,
+	})
+	binary.Write(&buf, binary.LittleEndian, macho.Section64{
+		Name: nameToArray16("__data"), Seg: nameToArray16("__DATA"),
+		Size: dataSize, Offset: uint32(dataOff),
+	})
+
+	binary.Write(&buf, binary.LittleEndian, macho.SymtabCmd{
+		Cmd: macho.LoadCmdSymtab, Len: uint32(symtabCmdSize),
+		Symoff: uint32(symOff), Nsyms: uint32(len(syms)),
+		Stroff: uint32(strOff), Strsize: uint32(strtab.Len()),
+	})
+
+	buf.Write(make([]byte, textSize))
+	buf.Write(make([]byte, dataSize))
+	for i, s := range syms {
+		binary.Write(&buf, binary.LittleEndian, macho.Nlist64{
+			Name: nameOff[i], Type: s.typ, Sect: s.sect, Desc: s.desc, Value: s.value,
+		})
+	}
+	strtab.WriteTo(&buf)
+
+	return buf.Bytes()
+}
+
+func TestOpenObjectFileMachO(t *testing.T) {
+	data := buildMachOFixture(t)
+
+	of, err := OpenObjectFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenObjectFile: %v", err)
+	}
+	if _, ok := of.(*machoObjectFile); !ok {
+		t.Fatalf("OpenObjectFile returned %T, want *machoObjectFile", of)
+	}
+
+	byName := make(map[string]Sym)
+	for _, sym := range of.Symbols() {
+		byName[sym.Name] = sym
+	}
+
+	cases := []struct {
+		name     string
+		isFunc   bool
+		isGlobal bool
+		isWeak   bool
+		defined  bool
+	}{
+		{"myfunc", true, true, false, true},
+		{"myglobal", false, true, false, true}, // data symbol: must NOT be IsFunc
+		{"localfunc", true, false, false, true},
+		{"weakfunc", true, false, true, true},
+		{"undefsym", false, true, false, false},
+	}
+	for _, tc := range cases {
+		sym, ok := byName[tc.name]
+		if !ok {
+			t.Fatalf("missing symbol %s", tc.name)
+		}
+		if sym.IsFunc != tc.isFunc || sym.IsGlobal != tc.isGlobal || sym.IsWeak != tc.isWeak || sym.Defined != tc.defined {
+			t.Errorf("%s: got {IsFunc:%v IsGlobal:%v IsWeak:%v Defined:%v}, want {%v %v %v %v}",
+				tc.name, sym.IsFunc, sym.IsGlobal, sym.IsWeak, sym.Defined, tc.isFunc, tc.isGlobal, tc.isWeak, tc.defined)
+		}
+	}
+
+	sec := of.Section("__DATA,__stack_args")
+	if sec != nil {
+		t.Error("Section(__DATA,__stack_args) = non-nil for a fixture with no __stack_args section, want nil")
+	}
+	sec = of.Section("__data")
+	if sec == nil || sec.Size() != dataSizeConst {
+		t.Errorf("Section(__data) = %v, want a section of size %d", sec, dataSizeConst)
+	}
+}
+
+const dataSizeConst = 8
+
+// buildPEFixture hand-builds a minimal raw COFF object (no MZ/PE image
+// wrapper, which debug/pe also accepts) with a .text section and a handful
+// of COFF symbols.
+func buildPEFixture(t *testing.T) []byte {
+	t.Helper()
+
+	const (
+		textOff  = 0
+		textSize = 16
+	)
+
+	type symSpec struct {
+		name          string
+		sectionNumber int16
+		typ           uint16
+		storageClass  uint8
+	}
+	const imageSymDTypeFunction = 0x20
+	const imageSymClassExternal = 2
+	syms := []symSpec{
+		{"myfunc", 1, imageSymDTypeFunction, imageSymClassExternal},
+		{"mydata", 1, 0, imageSymClassExternal},
+		{"undefsym", 0, imageSymDTypeFunction, imageSymClassExternal},
+	}
+
+	fileHdrSize := binary.Size(pe.FileHeader{})
+	sectHdrSize := 40 // SectionHeader32
+	dataOff := fileHdrSize + sectHdrSize
+	symOff := dataOff + textSize
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_AMD64,
+		NumberOfSections:     1,
+		PointerToSymbolTable: uint32(symOff),
+		NumberOfSymbols:      uint32(len(syms)),
+		SizeOfOptionalHeader: 0,
+	})
+
+	var sectName [8]byte
+	copy(sectName[:], ".text")
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Name                 [8]byte
+		VirtualSize          uint32
+		VirtualAddress       uint32
+		SizeOfRawData        uint32
+		PointerToRawData     uint32
+		PointerToRelocations uint32
+		PointerToLineNumbers uint32
+		NumberOfRelocations  uint16
+		NumberOfLineNumbers  uint16
+		Characteristics      uint32
+	}{
+		Name:             sectName,
+		SizeOfRawData:    textSize,
+		PointerToRawData: uint32(dataOff),
+	})
+
+	buf.Write(make([]byte, textSize))
+
+	for _, s := range syms {
+		var name [8]byte
+		copy(name[:], s.name)
+		binary.Write(&buf, binary.LittleEndian, pe.COFFSymbol{
+			Name:          name,
+			Value:         0,
+			SectionNumber: s.sectionNumber,
+			Type:          s.typ,
+			StorageClass:  s.storageClass,
+		})
+	}
+	binary.Write(&buf, binary.LittleEndian, uint32(4)) // empty string table (length includes itself)
+
+	return buf.Bytes()
+}
+
+func TestOpenObjectFilePE(t *testing.T) {
+	data := buildPEFixture(t)
+
+	of, err := OpenObjectFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenObjectFile: %v", err)
+	}
+	if _, ok := of.(*peObjectFile); !ok {
+		t.Fatalf("OpenObjectFile returned %T, want *peObjectFile", of)
+	}
+
+	byName := make(map[string]Sym)
+	for _, sym := range of.Symbols() {
+		byName[sym.Name] = sym
+	}
+
+	cases := []struct {
+		name     string
+		isFunc   bool
+		isGlobal bool
+		defined  bool
+	}{
+		{"myfunc", true, true, true},
+		{"mydata", false, true, true},
+		{"undefsym", true, false, false}, // no section => not external per IsGlobal's SectionNumber>0 check
+	}
+	for _, tc := range cases {
+		sym, ok := byName[tc.name]
+		if !ok {
+			t.Fatalf("missing symbol %s", tc.name)
+		}
+		if sym.IsFunc != tc.isFunc || sym.IsGlobal != tc.isGlobal || sym.Defined != tc.defined {
+			t.Errorf("%s: got {IsFunc:%v IsGlobal:%v Defined:%v}, want {%v %v %v}",
+				tc.name, sym.IsFunc, sym.IsGlobal, sym.Defined, tc.isFunc, tc.isGlobal, tc.defined)
+		}
+	}
+
+	sec := of.Section(".text")
+	if sec == nil || sec.Size() != textSize {
+		t.Errorf("Section(.text) = %v, want a section of size %d", sec, textSize)
+	}
+}
+
+const textSize = 16
+
+func TestOpenObjectFileUnrecognized(t *testing.T) {
+	if _, err := OpenObjectFile(bytes.NewReader([]byte("not an object file"))); err == nil {
+		t.Error("OpenObjectFile on garbage data: expected error, got nil")
+	}
+}